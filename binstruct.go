@@ -0,0 +1,20 @@
+package binstruct
+
+import "io"
+
+// Decoder reads and decodes binary data from an input stream, honoring
+// the `bin:"..."` struct tag grammar.
+type Decoder struct {
+	*unmarshal
+}
+
+// NewDecoder returns a new Decoder that reads from r.
+func NewDecoder(r io.ReadSeeker) *Decoder {
+	return &Decoder{unmarshal: &unmarshal{r: NewReader(r)}}
+}
+
+// Unmarshal reads binary data from r and stores it in the struct
+// pointed to by v.
+func Unmarshal(r io.ReadSeeker, v interface{}) error {
+	return NewDecoder(r).Unmarshal(v)
+}
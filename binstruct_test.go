@@ -0,0 +1,131 @@
+package binstruct
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestUnmarshal(t *testing.T) {
+	type header struct {
+		Magic   uint32 `bin:"magic:0xCAFEBABE"`
+		Version uint8
+	}
+
+	data := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x01}
+
+	var h header
+	if err := Unmarshal(bytes.NewReader(data), &h); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if h.Magic != 0xCAFEBABE || h.Version != 1 {
+		t.Fatalf("Unmarshal() = %+v, want Magic=0xCAFEBABE Version=1", h)
+	}
+}
+
+type celsius float64
+
+type celsiusCodec struct{}
+
+func (celsiusCodec) Unmarshal(r Reader, v reflect.Value) error {
+	raw, err := r.ReadInt16()
+	if err != nil {
+		return err
+	}
+	v.SetFloat(float64(raw) / 10)
+	return nil
+}
+
+func (celsiusCodec) Marshal(w Writer, v reflect.Value) error {
+	return w.WriteInt16(int16(v.Float() * 10))
+}
+
+func TestEncoderRegisterType(t *testing.T) {
+	type reading struct {
+		Temp celsius
+	}
+
+	buf := &bytes.Buffer{}
+	enc := NewEncoder(buf)
+	enc.RegisterType(celsius(0), celsiusCodec{})
+
+	if err := enc.Encode(&reading{Temp: 20}); err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := []byte{0x00, 0xC8}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("Encode() = %v, want %v", buf.Bytes(), want)
+	}
+}
+
+type pingMessage struct {
+	Payload uint8
+}
+
+func TestEncoderSwitchVariant(t *testing.T) {
+	type packet struct {
+		Kind uint8
+		Body interface{} `bin:"switch:Kind"`
+	}
+
+	p := packet{Kind: 1, Body: pingMessage{Payload: 0x42}}
+
+	got, err := Marshal(&p)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := []byte{0x01, 0x42}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Marshal() = %v, want %v", got, want)
+	}
+}
+
+type pongMessage struct {
+	Header struct {
+		Seq uint8
+	}
+	Payload uint8
+}
+
+func TestEncoderSwitchVariantNestedStruct(t *testing.T) {
+	type packet struct {
+		Kind uint8
+		Body interface{} `bin:"switch:Kind"`
+	}
+
+	body := pongMessage{Payload: 0x42}
+	body.Header.Seq = 7
+
+	p := packet{Kind: 2, Body: body}
+
+	got, err := Marshal(&p)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := []byte{0x02, 0x07, 0x42}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Marshal() = %v, want %v", got, want)
+	}
+}
+
+func TestDecoderRegisterType(t *testing.T) {
+	type reading struct {
+		Temp celsius
+	}
+
+	dec := NewDecoder(bytes.NewReader([]byte{0x00, 0xC8})) // 200 -> 20.0
+	dec.RegisterType(celsius(0), celsiusCodec{})
+
+	var r reading
+	if err := dec.Unmarshal(&r); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if r.Temp != 20 {
+		t.Fatalf("Temp = %v, want 20", r.Temp)
+	}
+}
@@ -0,0 +1,188 @@
+package binstruct
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+const bitOrderLSB = "lsb"
+
+// bitReader holds the bit-packing state of a decode, so consecutive
+// `bits:N` fields of one struct pack into the same underlying bytes. It
+// is realigned to a byte boundary on entering and leaving each struct.
+type bitReader struct {
+	buf  uint8
+	left uint
+}
+
+// align discards any partially consumed byte, so the next field (plain
+// or bit-packed) starts on a byte boundary.
+func (b *bitReader) align() {
+	b.buf = 0
+	b.left = 0
+}
+
+// readBits pulls n bits out of the stream, refilling the underlying
+// byte from r as needed, MSB-first unless order is "lsb".
+func (b *bitReader) readBits(r Reader, n int64, order string) (uint64, error) {
+	var result uint64
+	var shiftAcc uint
+
+	remaining := n
+	for remaining > 0 {
+		if b.left == 0 {
+			v, err := r.ReadUint8()
+			if err != nil {
+				return 0, err
+			}
+			b.buf = v
+			b.left = 8
+		}
+
+		take := uint(remaining)
+		if take > b.left {
+			take = b.left
+		}
+		mask := uint8(1<<take) - 1
+
+		if order == bitOrderLSB {
+			chunk := b.buf & mask
+			result |= uint64(chunk) << shiftAcc
+			shiftAcc += take
+			b.buf >>= take
+		} else {
+			shift := b.left - take
+			chunk := (b.buf >> shift) & mask
+			result = (result << take) | uint64(chunk)
+		}
+
+		b.left -= take
+		remaining -= int64(take)
+	}
+
+	return result, nil
+}
+
+// bitWriter holds the bit-packing state of an encode, mirroring
+// bitReader, so consecutive `bits:N` fields of one struct pack into the
+// same underlying bytes. It is flushed to a byte boundary on entering
+// and leaving each struct.
+type bitWriter struct {
+	buf  uint8
+	left uint
+}
+
+// align flushes any partially filled byte (zero-padded) and resets the
+// accumulator, so the next field starts on a byte boundary.
+func (b *bitWriter) align(w Writer) error {
+	if b.left == 0 {
+		return nil
+	}
+
+	err := w.WriteUint8(b.buf)
+	b.buf = 0
+	b.left = 0
+	return err
+}
+
+// writeBits packs the low n bits of value into the stream, flushing
+// full bytes to w as they fill up, MSB-first unless order is "lsb".
+func (b *bitWriter) writeBits(w Writer, value uint64, n int64, order string) error {
+	var shiftAcc uint
+
+	remaining := n
+	for remaining > 0 {
+		capacity := 8 - b.left
+		take := uint(remaining)
+		if take > capacity {
+			take = capacity
+		}
+		mask := uint64(1<<take) - 1
+
+		if order == bitOrderLSB {
+			chunk := uint8((value >> shiftAcc) & mask)
+			b.buf |= chunk << b.left
+			shiftAcc += take
+		} else {
+			shift := remaining - int64(take)
+			chunk := uint8((value >> uint(shift)) & mask)
+			b.buf |= chunk << (capacity - take)
+		}
+
+		b.left += take
+		remaining -= int64(take)
+
+		if b.left == 8 {
+			if err := w.WriteUint8(b.buf); err != nil {
+				return err
+			}
+			b.buf = 0
+			b.left = 0
+		}
+	}
+
+	return nil
+}
+
+// encodeBits writes fieldData.Bits bits of fieldValue.
+func (m *marshaler) encodeBits(fieldValue reflect.Value, fieldData *fieldReadData) error {
+	n := *fieldData.Bits
+	if n <= 0 || n > 64 {
+		return errors.Errorf("bits:%d out of range (1-64)", n)
+	}
+
+	var value uint64
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = uint64(fieldValue.Int()) & (uint64(1)<<uint(n) - 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = fieldValue.Uint()
+	default:
+		return errors.Errorf(`type "%s" not supported for bits`, fieldValue.Kind())
+	}
+
+	return errors.Wrap(m.bits.writeBits(m.w, value, n, fieldData.BitOrder), "write bits")
+}
+
+// decodeBits reads fieldData.Bits bits into fieldValue, sign-extending
+// for signed integer kinds.
+func (u *unmarshal) decodeBits(fieldValue reflect.Value, fieldData *fieldReadData) error {
+	n := *fieldData.Bits
+	if n <= 0 || n > 64 {
+		return errors.Errorf("bits:%d out of range (1-64)", n)
+	}
+
+	raw, err := u.bits.readBits(u.r, n, fieldData.BitOrder)
+	if err != nil {
+		return errors.Wrap(err, "read bits")
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value := int64(raw)
+		if n < 64 && raw&(1<<(uint(n)-1)) != 0 {
+			value -= int64(1) << uint(n)
+		}
+
+		if fieldValue.OverflowInt(value) {
+			return errors.Errorf("value %d overflows field of type %s", value, fieldValue.Type())
+		}
+		if fieldValue.CanSet() {
+			fieldValue.SetInt(value)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if fieldValue.OverflowUint(raw) {
+			return errors.Errorf("value %d overflows field of type %s", raw, fieldValue.Type())
+		}
+		if fieldValue.CanSet() {
+			fieldValue.SetUint(raw)
+		}
+
+	default:
+		return errors.Errorf(`type "%s" not supported for bits`, fieldValue.Kind())
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package binstruct
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBitsAlignAtStructBoundary(t *testing.T) {
+	type inner struct {
+		A uint8 `bin:"bits:3"`
+	}
+
+	type outer struct {
+		Inner inner
+		C     uint8 `bin:"bits:4"`
+		D     uint8 `bin:"bits:4"`
+	}
+
+	data := []byte{0b11100000, 0b10100101}
+
+	var o outer
+	if err := Unmarshal(bytes.NewReader(data), &o); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if o.Inner.A != 0b111 {
+		t.Fatalf("Inner.A = %b, want %b", o.Inner.A, 0b111)
+	}
+	if o.C != 0b1010 || o.D != 0b0101 {
+		t.Fatalf("C,D = %04b,%04b, want 1010,0101", o.C, o.D)
+	}
+}
+
+func TestMarshalBitsAlignAtStructBoundary(t *testing.T) {
+	type inner struct {
+		A uint8 `bin:"bits:3"`
+	}
+
+	type outer struct {
+		Inner inner
+		C     uint8 `bin:"bits:4"`
+		D     uint8 `bin:"bits:4"`
+	}
+
+	o := outer{Inner: inner{A: 0b111}, C: 0b1010, D: 0b0101}
+
+	got, err := Marshal(&o)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := []byte{0b11100000, 0b10100101}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Marshal() = %08b, want %08b", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package binstruct
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Codec lets callers plug in decoding (and, optionally, encoding) logic
+// for a type that binstruct has no built-in support for, such as
+// float16, fixed-point decimals, UUIDs, or timestamps.
+type Codec interface {
+	// Unmarshal reads a value of v's type from r and sets it into v.
+	Unmarshal(r Reader, v reflect.Value) error
+}
+
+// MarshalCodec is implemented by a Codec that also knows how to write
+// its type back out. It is consulted by Encoder when set.
+type MarshalCodec interface {
+	Codec
+
+	Marshal(w Writer, v reflect.Value) error
+}
+
+// RegisterType registers codec as the decoder for any field whose type
+// matches the type of sample. It can be used for top level fields as
+// well as slice/array elements, since both are resolved through the
+// same registry before falling back to the built-in Kind switch.
+func (u *unmarshal) RegisterType(sample interface{}, codec Codec) {
+	if u.codecs == nil {
+		u.codecs = make(map[reflect.Type]Codec)
+	}
+
+	u.codecs[reflect.TypeOf(sample)] = codec
+}
+
+func (u *unmarshal) codecFor(t reflect.Type) Codec {
+	if u.codecs == nil {
+		return nil
+	}
+
+	return u.codecs[t]
+}
+
+func callCodec(codec Codec, r Reader, fieldValue reflect.Value) error {
+	if !fieldValue.CanSet() {
+		return errors.Errorf(`type "%s" registered with a Codec is not settable`, fieldValue.Type())
+	}
+
+	return codec.Unmarshal(r, fieldValue)
+}
@@ -0,0 +1,21 @@
+package binstruct
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecoderRegisterTypeUnexportedFieldErrors(t *testing.T) {
+	type reading struct {
+		temp celsius // unexported: reflect can never Set this
+	}
+
+	dec := NewDecoder(bytes.NewReader([]byte{0x00, 0xC8}))
+	dec.RegisterType(celsius(0), celsiusCodec{})
+
+	var r reading
+	err := dec.Unmarshal(&r)
+	if err == nil {
+		t.Fatal("Unmarshal() error = nil, want error for codec on unexported field")
+	}
+}
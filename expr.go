@@ -0,0 +1,206 @@
+package binstruct
+
+import (
+	"strconv"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// exprResolver resolves an identifier appearing in a len/offset
+// expression (normally a sibling struct field) to its int64 value.
+type exprResolver func(name string) (int64, bool)
+
+type exprTokenKind int
+
+const (
+	exprTokenNumber exprTokenKind = iota
+	exprTokenIdent
+	exprTokenOp
+	exprTokenLParen
+	exprTokenRParen
+)
+
+type exprToken struct {
+	kind  exprTokenKind
+	text  string
+	value int64
+}
+
+var exprOpPrecedence = map[string]int{
+	"+": 1,
+	"-": 1,
+	"*": 2,
+	"/": 2,
+}
+
+// evalExpr evaluates a small arithmetic expression over int64
+// identifiers and literals, e.g. "FieldName*4+2" or
+// "HeaderSize+EntryIndex*RecordSize", resolving identifiers via
+// resolve. Supports +, -, *, /, and parentheses.
+func evalExpr(expr string, resolve exprResolver) (int64, error) {
+	tokens, err := tokenizeExpr(expr)
+	if err != nil {
+		return 0, errors.Wrap(err, "tokenize expression")
+	}
+
+	rpn, err := exprToRPN(tokens)
+	if err != nil {
+		return 0, errors.Wrap(err, "parse expression")
+	}
+
+	return evalRPN(rpn, resolve)
+}
+
+func tokenizeExpr(expr string) ([]exprToken, error) {
+	var tokens []exprToken
+
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '(':
+			tokens = append(tokens, exprToken{kind: exprTokenLParen, text: "("})
+			i++
+
+		case c == ')':
+			tokens = append(tokens, exprToken{kind: exprTokenRParen, text: ")"})
+			i++
+
+		case c == '+' || c == '-' || c == '*' || c == '/':
+			tokens = append(tokens, exprToken{kind: exprTokenOp, text: string(c)})
+			i++
+
+		case unicode.IsDigit(c):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == 'x' || runes[i] == 'X' ||
+				(runes[i] >= 'a' && runes[i] <= 'f') || (runes[i] >= 'A' && runes[i] <= 'F')) {
+				i++
+			}
+			text := string(runes[start:i])
+			n, err := strconv.ParseInt(text, 0, 64)
+			if err != nil {
+				return nil, errors.Errorf(`invalid number "%s"`, text)
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenNumber, text: text, value: n})
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: exprTokenIdent, text: string(runes[start:i])})
+
+		default:
+			return nil, errors.Errorf(`unexpected character "%c" in expression "%s"`, c, expr)
+		}
+	}
+
+	return tokens, nil
+}
+
+// exprToRPN converts infix tokens to reverse Polish notation using the
+// shunting-yard algorithm.
+func exprToRPN(tokens []exprToken) ([]exprToken, error) {
+	var output []exprToken
+	var ops []exprToken
+
+	for _, tok := range tokens {
+		switch tok.kind {
+		case exprTokenNumber, exprTokenIdent:
+			output = append(output, tok)
+
+		case exprTokenOp:
+			for len(ops) > 0 {
+				top := ops[len(ops)-1]
+				if top.kind != exprTokenOp || exprOpPrecedence[top.text] < exprOpPrecedence[tok.text] {
+					break
+				}
+				output = append(output, top)
+				ops = ops[:len(ops)-1]
+			}
+			ops = append(ops, tok)
+
+		case exprTokenLParen:
+			ops = append(ops, tok)
+
+		case exprTokenRParen:
+			found := false
+			for len(ops) > 0 {
+				top := ops[len(ops)-1]
+				ops = ops[:len(ops)-1]
+				if top.kind == exprTokenLParen {
+					found = true
+					break
+				}
+				output = append(output, top)
+			}
+			if !found {
+				return nil, errors.New("mismatched parentheses")
+			}
+		}
+	}
+
+	for len(ops) > 0 {
+		top := ops[len(ops)-1]
+		ops = ops[:len(ops)-1]
+		if top.kind == exprTokenLParen {
+			return nil, errors.New("mismatched parentheses")
+		}
+		output = append(output, top)
+	}
+
+	return output, nil
+}
+
+func evalRPN(rpn []exprToken, resolve exprResolver) (int64, error) {
+	var stack []int64
+
+	for _, tok := range rpn {
+		switch tok.kind {
+		case exprTokenNumber:
+			stack = append(stack, tok.value)
+
+		case exprTokenIdent:
+			v, ok := resolve(tok.text)
+			if !ok {
+				return 0, errors.Errorf(`field "%s" not found`, tok.text)
+			}
+			stack = append(stack, v)
+
+		case exprTokenOp:
+			if len(stack) < 2 {
+				return 0, errors.Errorf(`invalid expression near "%s"`, tok.text)
+			}
+			b := stack[len(stack)-1]
+			a := stack[len(stack)-2]
+			stack = stack[:len(stack)-2]
+
+			var r int64
+			switch tok.text {
+			case "+":
+				r = a + b
+			case "-":
+				r = a - b
+			case "*":
+				r = a * b
+			case "/":
+				if b == 0 {
+					return 0, errors.New("division by zero")
+				}
+				r = a / b
+			}
+			stack = append(stack, r)
+		}
+	}
+
+	if len(stack) != 1 {
+		return 0, errors.New("invalid expression")
+	}
+
+	return stack[0], nil
+}
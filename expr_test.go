@@ -0,0 +1,52 @@
+package binstruct
+
+import "testing"
+
+func TestEvalExprArithmetic(t *testing.T) {
+	resolve := func(name string) (int64, bool) {
+		switch name {
+		case "EntryIndex":
+			return 3, true
+		case "RecordSize":
+			return 4, true
+		default:
+			return 0, false
+		}
+	}
+
+	got, err := evalExpr("EntryIndex*RecordSize+2", resolve)
+	if err != nil {
+		t.Fatalf("evalExpr() error = %v", err)
+	}
+	if got != 14 {
+		t.Fatalf("evalExpr() = %d, want 14", got)
+	}
+}
+
+func TestEvalExprParentheses(t *testing.T) {
+	resolve := func(name string) (int64, bool) { return 0, false }
+
+	got, err := evalExpr("(2+3)*4", resolve)
+	if err != nil {
+		t.Fatalf("evalExpr() error = %v", err)
+	}
+	if got != 20 {
+		t.Fatalf("evalExpr() = %d, want 20", got)
+	}
+}
+
+func TestEvalExprUnknownIdent(t *testing.T) {
+	resolve := func(name string) (int64, bool) { return 0, false }
+
+	if _, err := evalExpr("Missing+1", resolve); err == nil {
+		t.Fatal("evalExpr() error = nil, want error for unresolved identifier")
+	}
+}
+
+func TestEvalExprDivisionByZero(t *testing.T) {
+	resolve := func(name string) (int64, bool) { return 0, false }
+
+	if _, err := evalExpr("1/0", resolve); err == nil {
+		t.Fatal("evalExpr() error = nil, want error for division by zero")
+	}
+}
@@ -0,0 +1,384 @@
+package binstruct
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// An InvalidMarshalError describes an invalid argument passed to Marshal
+// or Encoder.Encode. (The argument must be a non-nil pointer or a
+// struct.)
+type InvalidMarshalError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidMarshalError) Error() string {
+	if e.Type == nil {
+		return "binstruct: Marshal(nil)"
+	}
+
+	return "binstruct: Marshal(unsupported " + e.Type.String() + ")"
+}
+
+// Encoder writes binary data honoring the same `bin:"..."` tag grammar
+// that Decoder consumes.
+type Encoder struct {
+	w      Writer
+	codecs map[reflect.Type]MarshalCodec
+}
+
+// NewEncoder returns a new Encoder that writes to w. Seek-based `offset`
+// tags are only honored when w also implements io.Seeker.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: NewWriter(w)}
+}
+
+// RegisterType registers codec as the encoder for any field whose type
+// matches the type of sample, mirroring (*Decoder).RegisterType.
+func (enc *Encoder) RegisterType(sample interface{}, codec MarshalCodec) {
+	if enc.codecs == nil {
+		enc.codecs = make(map[reflect.Type]MarshalCodec)
+	}
+
+	enc.codecs[reflect.TypeOf(sample)] = codec
+}
+
+// Encode writes v, which must be a pointer to a struct (or a struct),
+// to the Encoder's underlying writer.
+func (enc *Encoder) Encode(v interface{}) error {
+	m := &marshaler{w: enc.w, codecs: enc.codecs}
+	return m.marshal(v, nil)
+}
+
+// Marshal returns the binary encoding of v, using the same `bin:"..."`
+// struct tags that Unmarshal consumes.
+func Marshal(v interface{}) ([]byte, error) {
+	buf := &seekableBuffer{}
+
+	enc := NewEncoder(buf)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.buf, nil
+}
+
+// seekableBuffer is an in-memory io.Writer/io.Seeker backing Marshal, so
+// that structs using `offset:` tags can round-trip through the
+// convenience entry point just like Unmarshal honors them when reading.
+type seekableBuffer struct {
+	buf []byte
+	pos int64
+}
+
+func (b *seekableBuffer) Write(p []byte) (int, error) {
+	end := b.pos + int64(len(p))
+	if end > int64(len(b.buf)) {
+		grown := make([]byte, end)
+		copy(grown, b.buf)
+		b.buf = grown
+	}
+
+	n := copy(b.buf[b.pos:end], p)
+	b.pos = end
+
+	return n, nil
+}
+
+func (b *seekableBuffer) Seek(offset int64, whence int) (int64, error) {
+	var newPos int64
+	switch whence {
+	case io.SeekStart:
+		newPos = offset
+	case io.SeekCurrent:
+		newPos = b.pos + offset
+	case io.SeekEnd:
+		newPos = int64(len(b.buf)) + offset
+	default:
+		return 0, errors.Errorf("seekableBuffer: invalid whence %d", whence)
+	}
+
+	if newPos < 0 {
+		return 0, errors.New("seekableBuffer: negative position")
+	}
+
+	b.pos = newPos
+	return b.pos, nil
+}
+
+type marshaler struct {
+	w      Writer
+	codecs map[reflect.Type]MarshalCodec
+	bits   bitWriter
+}
+
+func (m *marshaler) codecFor(t reflect.Type) MarshalCodec {
+	if m.codecs == nil {
+		return nil
+	}
+
+	return m.codecs[t]
+}
+
+func (m *marshaler) marshal(v interface{}, parentStructValues []reflect.Value) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return &InvalidMarshalError{reflect.TypeOf(v)}
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return &InvalidMarshalError{reflect.TypeOf(v)}
+	}
+
+	// Struct fields need to be addressable so that a nested struct or
+	// interface field can recurse via fieldValue.Addr()/Interface(). A
+	// struct passed by value (rather than by pointer) isn't, so copy it
+	// into an addressable value first.
+	if !rv.CanAddr() {
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(rv)
+		rv = cp
+	}
+
+	structValue := rv
+	numField := structValue.NumField()
+
+	// bits:N fields only pack together within a single struct; entering
+	// a new one always starts on a byte boundary.
+	if err := m.bits.align(m.w); err != nil {
+		return errors.Wrap(err, "align bits")
+	}
+
+	valueType := structValue.Type()
+	for i := 0; i < numField; i++ {
+		fieldType := valueType.Field(i)
+		tags, err := parseTag(fieldType.Tag.Get(tagName))
+		if err != nil {
+			return errors.Wrapf(err, `failed parseTag for field "%s"`, fieldType.Name)
+		}
+
+		fieldData, err := parseReadDataFromTags(structValue, parentStructValues, tags)
+		if err != nil {
+			return errors.Wrapf(err, `failed parse ReadData from tags for field "%s"`, fieldType.Name)
+		}
+
+		fieldValue := structValue.Field(i)
+		err = m.setFieldToWriter(structValue, fieldValue, fieldData, parentStructValues)
+		if err != nil {
+			return errors.Wrapf(err, `failed write value from field "%s"`, fieldType.Name)
+		}
+	}
+
+	// Leaving the struct also lands on a byte boundary, so a nested
+	// struct's trailing partial bits never bleed into the parent's next
+	// bits:N field.
+	if err := m.bits.align(m.w); err != nil {
+		return errors.Wrap(err, "align bits")
+	}
+
+	return nil
+}
+
+func (m *marshaler) setFieldToWriter(structValue, fieldValue reflect.Value, fieldData *fieldReadData, parentStructValues []reflect.Value) error {
+	if fieldData == nil {
+		fieldData = &fieldReadData{}
+	}
+
+	if fieldData.Ignore {
+		return nil
+	}
+
+	if fieldData.Align {
+		if err := m.bits.align(m.w); err != nil {
+			return errors.Wrap(err, "align bits")
+		}
+	}
+
+	err := setOffsetWriter(m.w, fieldData)
+	if err != nil {
+		return errors.Wrap(err, "set offset")
+	}
+
+	if fieldData.Bits != nil {
+		return m.encodeBits(fieldValue, fieldData)
+	}
+
+	if fieldData.FuncName != "" {
+		okCallFunc, err := callFuncWrite(m.w, fieldData.FuncName, structValue, fieldValue)
+		if err != nil {
+			return errors.Wrap(err, "call custom func")
+		}
+
+		if !okCallFunc {
+			for i := len(parentStructValues) - 1; i >= 0; i-- {
+				sv := parentStructValues[i]
+				okCallFunc, err = callFuncWrite(m.w, fieldData.FuncName, sv, fieldValue)
+				if err != nil {
+					return errors.Wrap(err, "call custom func")
+				}
+
+				if okCallFunc {
+					return nil
+				}
+			}
+
+			return errors.Errorf(
+				`failed call method, expected method: func (*%s) %s(w binstruct.Writer) error {}`,
+				structValue.Type().Name(), fieldData.FuncName,
+			)
+		}
+
+		return nil
+	}
+
+	if codec := m.codecFor(fieldValue.Type()); codec != nil {
+		return codec.Marshal(m.w, fieldValue)
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value := fieldValue.Int()
+
+		// See the analogous comment in unmarshal.go's setValueToField: a
+		// len:N tag always wins over the field's own Kind.
+		switch {
+		case fieldData.Length != nil && *fieldData.Length == 1 || fieldData.Length == nil && fieldValue.Kind() == reflect.Int8:
+			if int64(int8(value)) != value {
+				return errors.Errorf("value %d overflows len:1 field", value)
+			}
+			return m.w.WriteInt8(int8(value))
+		case fieldData.Length != nil && *fieldData.Length == 2 || fieldData.Length == nil && fieldValue.Kind() == reflect.Int16:
+			if int64(int16(value)) != value {
+				return errors.Errorf("value %d overflows len:2 field", value)
+			}
+			return m.w.WriteInt16(int16(value))
+		case fieldData.Length != nil && *fieldData.Length == 4 || fieldData.Length == nil && fieldValue.Kind() == reflect.Int32:
+			if int64(int32(value)) != value {
+				return errors.Errorf("value %d overflows len:4 field", value)
+			}
+			return m.w.WriteInt32(int32(value))
+		case fieldData.Length != nil && *fieldData.Length == 8 || fieldData.Length == nil && fieldValue.Kind() == reflect.Int64:
+			return m.w.WriteInt64(value)
+		default: // reflect.Int:
+			return errors.New("need set tag with len or use int8/int16/int32/int64")
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value := fieldValue.Uint()
+
+		// See the analogous comment in the Int case above: len:N always
+		// wins over Kind.
+		switch {
+		case fieldData.Length != nil && *fieldData.Length == 1 || fieldData.Length == nil && fieldValue.Kind() == reflect.Uint8:
+			if uint64(uint8(value)) != value {
+				return errors.Errorf("value %d overflows len:1 field", value)
+			}
+			return m.w.WriteUint8(uint8(value))
+		case fieldData.Length != nil && *fieldData.Length == 2 || fieldData.Length == nil && fieldValue.Kind() == reflect.Uint16:
+			if uint64(uint16(value)) != value {
+				return errors.Errorf("value %d overflows len:2 field", value)
+			}
+			return m.w.WriteUint16(uint16(value))
+		case fieldData.Length != nil && *fieldData.Length == 4 || fieldData.Length == nil && fieldValue.Kind() == reflect.Uint32:
+			if uint64(uint32(value)) != value {
+				return errors.Errorf("value %d overflows len:4 field", value)
+			}
+			return m.w.WriteUint32(uint32(value))
+		case fieldData.Length != nil && *fieldData.Length == 8 || fieldData.Length == nil && fieldValue.Kind() == reflect.Uint64:
+			return m.w.WriteUint64(value)
+		default: // reflect.Uint:
+			return errors.New("need set tag with len or use uint8/uint16/uint32/uint64")
+		}
+	case reflect.Float32:
+		return m.w.WriteFloat32(float32(fieldValue.Float()))
+	case reflect.Float64:
+		return m.w.WriteFloat64(fieldValue.Float())
+	case reflect.Bool:
+		return m.w.WriteBool(fieldValue.Bool())
+	case reflect.String:
+		if fieldData.Length == nil {
+			return errors.New("need set tag with len for string")
+		}
+
+		str := fieldValue.String()
+		if int64(len(str)) > *fieldData.Length {
+			return errors.Errorf("string %q is longer than len:%d", str, *fieldData.Length)
+		}
+
+		b := make([]byte, *fieldData.Length)
+		copy(b, str)
+
+		_, err := m.w.WriteBytes(b)
+		return err
+	case reflect.Slice, reflect.Array:
+		length := fieldValue.Len()
+
+		for i := 0; i < length; i++ {
+			err := m.setFieldToWriter(structValue, fieldValue.Index(i), fieldData.ElemFieldData, parentStructValues)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return errors.Wrap(
+			m.marshal(fieldValue.Addr().Interface(), append(parentStructValues, structValue)),
+			"marshal struct",
+		)
+	case reflect.Interface:
+		if fieldData.Switch == "" {
+			return errors.New("need set tag with switch for interface field")
+		}
+		if fieldValue.IsNil() {
+			return errors.New("cannot marshal nil interface field")
+		}
+
+		return errors.Wrap(
+			m.marshal(fieldValue.Interface(), append(parentStructValues, structValue)),
+			"marshal variant",
+		)
+	default:
+		return errors.New(`type "` + fieldValue.Kind().String() + `" not supported`)
+	}
+}
+
+// callFuncWrite calls a user-supplied encoding hook of shape
+//
+//	func (*T) Name(w binstruct.Writer) error {}
+//
+// analogous to the reader hook callFunc uses for Unmarshal.
+func callFuncWrite(w Writer, funcName string, structValue, fieldValue reflect.Value) (bool, error) {
+	m := structValue.Addr().MethodByName(funcName)
+
+	writerType := reflect.TypeOf((*Writer)(nil)).Elem()
+	if !m.IsValid() || m.Type().NumIn() != 1 || m.Type().In(0) != writerType {
+		return false, nil
+	}
+
+	ret := m.Call([]reflect.Value{reflect.ValueOf(w)})
+
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	if len(ret) == 1 && ret[0].Type() == errorType {
+		if !ret[0].IsNil() {
+			return true, ret[0].Interface().(error)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func setOffsetWriter(w Writer, fieldData *fieldReadData) error {
+	for _, v := range fieldData.Offsets {
+		_, err := w.Seek(v.Offset, v.Whence)
+		if err != nil {
+			return errors.Wrap(err, "seek")
+		}
+	}
+
+	return nil
+}
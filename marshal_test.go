@@ -0,0 +1,168 @@
+package binstruct
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMarshalBasicTypes(t *testing.T) {
+	type basic struct {
+		I8    int8
+		U16   uint16
+		F32   float32
+		F64   float64
+		Flag  bool
+		Name  string  `bin:"len:5"`
+		Items []uint8 `bin:"len:3"`
+		Tail  [2]uint8
+	}
+
+	b := basic{
+		I8:    -1,
+		U16:   0x0102,
+		F32:   1,
+		F64:   2,
+		Flag:  true,
+		Name:  "abcde",
+		Items: []uint8{7, 8, 9},
+		Tail:  [2]uint8{0xAA, 0xBB},
+	}
+
+	data, err := Marshal(&b)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	var got basic
+	if err := Unmarshal(bytes.NewReader(data), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if got.I8 != b.I8 || got.U16 != b.U16 || got.F32 != b.F32 || got.F64 != b.F64 ||
+		got.Flag != b.Flag || got.Name != b.Name || !bytes.Equal(got.Items, b.Items) || got.Tail != b.Tail {
+		t.Fatalf("round trip = %+v, want %+v", got, b)
+	}
+}
+
+func TestMarshalLenWidensPastFieldKind(t *testing.T) {
+	type widened struct {
+		A int32 `bin:"len:8"`
+	}
+
+	data, err := Marshal(&widened{A: 42})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	if len(data) != 8 {
+		t.Fatalf("len(data) = %d, want 8", len(data))
+	}
+
+	var got widened
+	if err := Unmarshal(bytes.NewReader(data), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.A != 42 {
+		t.Fatalf("A = %d, want 42", got.A)
+	}
+}
+
+func TestMarshalStringPadsToLength(t *testing.T) {
+	type rec struct {
+		Name string `bin:"len:10"`
+		Next uint8
+	}
+
+	data, err := Marshal(&rec{Name: "abc", Next: 0xFF})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	if len(data) != 11 {
+		t.Fatalf("len(data) = %d, want 11", len(data))
+	}
+
+	want := append([]byte("abc"), make([]byte, 7)...)
+	want = append(want, 0xFF)
+	if !bytes.Equal(data, want) {
+		t.Fatalf("data = %v, want %v", data, want)
+	}
+}
+
+func TestMarshalStringTooLong(t *testing.T) {
+	type rec struct {
+		Name string `bin:"len:3"`
+	}
+
+	_, err := Marshal(&rec{Name: "abcdef"})
+	if err == nil {
+		t.Fatal("Marshal() error = nil, want error for string longer than len:N")
+	}
+}
+
+func TestMarshalIntOverflowsLen(t *testing.T) {
+	type rec struct {
+		A int64 `bin:"len:1"`
+	}
+
+	_, err := Marshal(&rec{A: 300})
+	if err == nil {
+		t.Fatal("Marshal() error = nil, want error for value overflowing len:1 field")
+	}
+}
+
+func TestMarshalUintOverflowsLen(t *testing.T) {
+	type rec struct {
+		A uint64 `bin:"len:1"`
+	}
+
+	_, err := Marshal(&rec{A: 300})
+	if err == nil {
+		t.Fatal("Marshal() error = nil, want error for value overflowing len:1 field")
+	}
+}
+
+func TestMarshalNonPointerNestedStruct(t *testing.T) {
+	type inner struct {
+		A uint8
+	}
+	type outer struct {
+		Inner inner
+		B     uint8
+	}
+
+	data, err := Marshal(outer{Inner: inner{A: 1}, B: 2})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := []byte{1, 2}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("Marshal() = %v, want %v", data, want)
+	}
+}
+
+func TestMarshalOffset(t *testing.T) {
+	type rec struct {
+		A uint8
+		B uint8 `bin:"offset:4"`
+	}
+
+	data, err := Marshal(&rec{A: 1, B: 2})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := []byte{1, 0, 0, 0, 2}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("Marshal() = %v, want %v", data, want)
+	}
+
+	var got rec
+	if err := Unmarshal(bytes.NewReader(data), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.A != 1 || got.B != 2 {
+		t.Fatalf("Unmarshal() = %+v, want A=1 B=2", got)
+	}
+}
@@ -0,0 +1,151 @@
+package binstruct
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Reader is the interface used by the unmarshaler to pull typed values
+// out of the underlying stream. It wraps io.Reader/io.Seeker with the
+// helpers needed to decode the `bin` tag grammar.
+type Reader interface {
+	io.Reader
+	io.Seeker
+
+	ReadBytes(n int) (int64, []byte, error)
+
+	// Peek returns the next n bytes without advancing the read
+	// position, used to check for `until` sentinel values before
+	// deciding whether to decode another slice element.
+	Peek(n int) ([]byte, error)
+
+	ReadBool() (bool, error)
+
+	ReadInt8() (int8, error)
+	ReadInt16() (int16, error)
+	ReadInt32() (int32, error)
+	ReadInt64() (int64, error)
+
+	ReadUint8() (uint8, error)
+	ReadUint16() (uint16, error)
+	ReadUint32() (uint32, error)
+	ReadUint64() (uint64, error)
+
+	ReadFloat32() (float32, error)
+	ReadFloat64() (float64, error)
+}
+
+type reader struct {
+	r         io.ReadSeeker
+	byteOrder binary.ByteOrder
+}
+
+// NewReader returns a Reader that decodes big-endian values from r.
+func NewReader(r io.ReadSeeker) Reader {
+	return &reader{r: r, byteOrder: binary.BigEndian}
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *reader) Seek(offset int64, whence int) (int64, error) {
+	return r.r.Seek(offset, whence)
+}
+
+func (r *reader) ReadBytes(n int) (int64, []byte, error) {
+	buf := make([]byte, n)
+	read, err := io.ReadFull(r.r, buf)
+	if err != nil {
+		return int64(read), buf, errors.Wrap(err, "read bytes")
+	}
+	return int64(read), buf, nil
+}
+
+func (r *reader) Peek(n int) ([]byte, error) {
+	read, b, err := r.ReadBytes(n)
+
+	// Only rewind by what was actually consumed from the stream; ReadBytes
+	// always returns a full n-length buffer even on a short read, so
+	// seeking back by len(b) would run past the start of what we read.
+	if read > 0 {
+		if _, serr := r.Seek(-read, io.SeekCurrent); serr != nil {
+			return b, errors.Wrap(serr, "seek back after peek")
+		}
+	}
+
+	return b, err
+}
+
+func (r *reader) ReadBool() (bool, error) {
+	v, err := r.ReadUint8()
+	return v != 0, err
+}
+
+func (r *reader) ReadInt8() (int8, error) {
+	_, b, err := r.ReadBytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return int8(b[0]), nil
+}
+
+func (r *reader) ReadInt16() (int16, error) {
+	v, err := r.ReadUint16()
+	return int16(v), err
+}
+
+func (r *reader) ReadInt32() (int32, error) {
+	v, err := r.ReadUint32()
+	return int32(v), err
+}
+
+func (r *reader) ReadInt64() (int64, error) {
+	v, err := r.ReadUint64()
+	return int64(v), err
+}
+
+func (r *reader) ReadUint8() (uint8, error) {
+	_, b, err := r.ReadBytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *reader) ReadUint16() (uint16, error) {
+	_, b, err := r.ReadBytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return r.byteOrder.Uint16(b), nil
+}
+
+func (r *reader) ReadUint32() (uint32, error) {
+	_, b, err := r.ReadBytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return r.byteOrder.Uint32(b), nil
+}
+
+func (r *reader) ReadUint64() (uint64, error) {
+	_, b, err := r.ReadBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return r.byteOrder.Uint64(b), nil
+}
+
+func (r *reader) ReadFloat32() (float32, error) {
+	v, err := r.ReadUint32()
+	return math.Float32frombits(v), err
+}
+
+func (r *reader) ReadFloat64() (float64, error) {
+	v, err := r.ReadUint64()
+	return math.Float64frombits(v), err
+}
@@ -0,0 +1,60 @@
+package binstruct
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestReaderPeek(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte{1, 2, 3}))
+
+	for i := 0; i < 3; i++ {
+		b, err := r.Peek(1)
+		if err != nil {
+			t.Fatalf("Peek() error = %v", err)
+		}
+		if b[0] != 1 {
+			t.Fatalf("Peek() did not repeat the same byte on call %d, got %v", i, b)
+		}
+	}
+
+	v, err := r.ReadUint8()
+	if err != nil {
+		t.Fatalf("ReadUint8() error = %v", err)
+	}
+	if v != 1 {
+		t.Fatalf("ReadUint8() = %d, want 1", v)
+	}
+
+	v, err = r.ReadUint8()
+	if err != nil {
+		t.Fatalf("ReadUint8() error = %v", err)
+	}
+	if v != 2 {
+		t.Fatalf("ReadUint8() = %d, want 2", v)
+	}
+}
+
+// TestReaderPeekAtEOF reproduces a reviewer-reported regression: peeking
+// past the end of the stream must surface io.EOF instead of rewinding
+// the reader into already-consumed bytes.
+func TestReaderPeekAtEOF(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte{1, 2, 3}))
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.ReadUint8(); err != nil {
+			t.Fatalf("ReadUint8() error = %v", err)
+		}
+	}
+
+	if _, err := r.Peek(1); errors.Cause(err) != io.EOF {
+		t.Fatalf("Peek() at EOF error = %v, want io.EOF", err)
+	}
+
+	if _, err := r.ReadUint8(); errors.Cause(err) != io.EOF {
+		t.Fatalf("ReadUint8() after Peek() at EOF error = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,69 @@
+package binstruct
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUnmarshalLenEOF(t *testing.T) {
+	type rec struct {
+		Items []uint8 `bin:"len:eof"`
+	}
+
+	var r rec
+	if err := Unmarshal(bytes.NewReader([]byte{1, 2, 3}), &r); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !bytes.Equal(r.Items, []byte{1, 2, 3}) {
+		t.Fatalf("Items = %v, want [1 2 3]", r.Items)
+	}
+}
+
+func TestUnmarshalUntilSentinel(t *testing.T) {
+	type rec struct {
+		Items []uint8 `bin:"until:0xFF"`
+		Tail  uint8
+	}
+
+	var r rec
+	data := []byte{1, 2, 3, 0xFF, 9}
+	if err := Unmarshal(bytes.NewReader(data), &r); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !bytes.Equal(r.Items, []byte{1, 2, 3}) {
+		t.Fatalf("Items = %v, want [1 2 3]", r.Items)
+	}
+	if r.Tail != 9 {
+		t.Fatalf("Tail = %d, want 9", r.Tail)
+	}
+}
+
+func TestUnmarshalUntilSentinelMissing(t *testing.T) {
+	type rec struct {
+		Items []uint8 `bin:"until:0xFF"`
+	}
+
+	var r rec
+	err := Unmarshal(bytes.NewReader([]byte{1, 2, 3}), &r)
+	if err == nil {
+		t.Fatal("Unmarshal() error = nil, want error when sentinel is never found")
+	}
+}
+
+func TestUnmarshalUntilKeepTerminator(t *testing.T) {
+	type rec struct {
+		Items []uint8 `bin:"until:0xFF,keepTerminator"`
+	}
+
+	var r rec
+	data := []byte{1, 2, 0xFF}
+	if err := Unmarshal(bytes.NewReader(data), &r); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !bytes.Equal(r.Items, []byte{1, 2, 0xFF}) {
+		t.Fatalf("Items = %v, want [1 2 255]", r.Items)
+	}
+}
@@ -0,0 +1,284 @@
+package binstruct
+
+import (
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// tagName is the struct tag key parsed by the (un)marshaler, e.g.
+// `bin:"len:4,offset:10"`.
+const tagName = "bin"
+
+// OffsetReadData describes a single seek that must happen before a field
+// is read or written.
+type OffsetReadData struct {
+	Offset int64
+	Whence int
+}
+
+// fieldReadData holds the parsed `bin` tag options for a single struct
+// field, resolved against any already-decoded sibling fields.
+type fieldReadData struct {
+	Ignore   bool
+	FuncName string
+
+	Length  *int64
+	Offsets []OffsetReadData
+
+	// ElemFieldData carries the tag data that applies to each element of
+	// a slice or array field.
+	ElemFieldData *fieldReadData
+
+	Validate *fieldValidateData
+
+	// Switch names the sibling field whose value discriminates which
+	// concrete type to decode into an interface field, e.g.
+	// `bin:"switch:TypeField"`.
+	Switch string
+
+	// Bits, when set, packs the field into N bits of the current byte
+	// instead of reading whole bytes, e.g. `bin:"bits:3"`.
+	Bits *int64
+	// BitOrder is "msb" (default) or "lsb", set via `bin:"order:lsb"`.
+	BitOrder string
+	// Align discards any partially consumed bit byte before the field
+	// is read, set via `bin:"align"`.
+	Align bool
+
+	// LenEOF, set via `bin:"len:eof"`, reads slice elements until
+	// io.EOF instead of a fixed count.
+	LenEOF bool
+	// Until, set via `bin:"until:0x00"` or `bin:"until:FieldName"`,
+	// reads slice elements until one matching this sentinel is seen.
+	// The terminator is consumed but not appended unless KeepTerminator
+	// is set.
+	Until          *int64
+	KeepTerminator bool
+}
+
+// fieldValidateData holds the parsed validation tag options for a field,
+// checked immediately after the field is decoded.
+type fieldValidateData struct {
+	Min *int64
+	Max *int64
+
+	In []string
+
+	Regex *regexp.Regexp
+
+	Magic *uint64
+}
+
+// parseTag splits a raw `bin` tag into its comma separated options.
+// A tag of "" has no options; a tag of "-" yields the single "-"
+// option, which marks the field ignored. Splitting is quote-aware so a
+// comma inside a `"..."` option value, e.g. `regex:"^[0-9]{2,4}$"`,
+// doesn't get shredded into two options.
+func parseTag(tag string) ([]string, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	var tags []string
+	var cur strings.Builder
+	inQuotes := false
+
+	for _, r := range tag {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			cur.WriteRune(r)
+		case r == ',' && !inQuotes:
+			tags = append(tags, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+
+	if inQuotes {
+		return nil, errors.Errorf(`unbalanced quotes in tag "%s"`, tag)
+	}
+
+	tags = append(tags, cur.String())
+
+	return tags, nil
+}
+
+// parseReadDataFromTags turns the options produced by parseTag into a
+// fieldReadData, evaluating any len/offset/min/max expressions against
+// fields already decoded in structValue or one of parentStructValues.
+func parseReadDataFromTags(structValue reflect.Value, parentStructValues []reflect.Value, tags []string) (*fieldReadData, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	data := &fieldReadData{}
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+
+		switch {
+		case tag == "-" || tag == "ignore":
+			data.Ignore = true
+
+		case strings.HasPrefix(tag, "len:"):
+			value := unquoteTagValue(strings.TrimPrefix(tag, "len:"))
+			if value == "eof" {
+				data.LenEOF = true
+				break
+			}
+
+			length, err := resolveIntExpr(structValue, parentStructValues, value)
+			if err != nil {
+				return nil, errors.Wrap(err, "resolve len")
+			}
+			data.Length = &length
+
+		case strings.HasPrefix(tag, "until:"):
+			value := unquoteTagValue(strings.TrimPrefix(tag, "until:"))
+			until, err := resolveIntExpr(structValue, parentStructValues, value)
+			if err != nil {
+				return nil, errors.Wrap(err, "resolve until")
+			}
+			data.Until = &until
+
+		case tag == "keepTerminator":
+			data.KeepTerminator = true
+
+		case strings.HasPrefix(tag, "offset:"):
+			value := unquoteTagValue(strings.TrimPrefix(tag, "offset:"))
+			offset, err := resolveIntExpr(structValue, parentStructValues, value)
+			if err != nil {
+				return nil, errors.Wrap(err, "resolve offset")
+			}
+			data.Offsets = append(data.Offsets, OffsetReadData{Offset: offset, Whence: io.SeekStart})
+
+		case strings.HasPrefix(tag, "min:"):
+			value := unquoteTagValue(strings.TrimPrefix(tag, "min:"))
+			min, err := resolveIntExpr(structValue, parentStructValues, value)
+			if err != nil {
+				return nil, errors.Wrap(err, "resolve min")
+			}
+			data.validate().Min = &min
+
+		case strings.HasPrefix(tag, "max:"):
+			value := unquoteTagValue(strings.TrimPrefix(tag, "max:"))
+			max, err := resolveIntExpr(structValue, parentStructValues, value)
+			if err != nil {
+				return nil, errors.Wrap(err, "resolve max")
+			}
+			data.validate().Max = &max
+
+		case strings.HasPrefix(tag, "in:"):
+			value := unquoteTagValue(strings.TrimPrefix(tag, "in:"))
+			data.validate().In = strings.Split(value, "|")
+
+		case strings.HasPrefix(tag, "regex:"):
+			value := unquoteTagValue(strings.TrimPrefix(tag, "regex:"))
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, errors.Wrap(err, "compile regex")
+			}
+			data.validate().Regex = re
+
+		case strings.HasPrefix(tag, "switch:"):
+			data.Switch = unquoteTagValue(strings.TrimPrefix(tag, "switch:"))
+
+		case strings.HasPrefix(tag, "bits:"):
+			value := unquoteTagValue(strings.TrimPrefix(tag, "bits:"))
+			bits, err := resolveIntExpr(structValue, parentStructValues, value)
+			if err != nil {
+				return nil, errors.Wrap(err, "resolve bits")
+			}
+			data.Bits = &bits
+
+		case strings.HasPrefix(tag, "order:"):
+			data.BitOrder = unquoteTagValue(strings.TrimPrefix(tag, "order:"))
+
+		case tag == "align":
+			data.Align = true
+
+		case strings.HasPrefix(tag, "magic:"):
+			value := strings.TrimPrefix(tag, "magic:")
+			magic, err := strconv.ParseUint(value, 0, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse magic")
+			}
+			data.validate().Magic = &magic
+
+		default:
+			// Anything left over is the name of a custom (un)marshal
+			// method, e.g. `bin:"ReadHeader"`.
+			data.FuncName = tag
+		}
+	}
+
+	return data, nil
+}
+
+// validate lazily allocates the validation data for a field so the
+// common case of no validation tags costs nothing.
+func (d *fieldReadData) validate() *fieldValidateData {
+	if d.Validate == nil {
+		d.Validate = &fieldValidateData{}
+	}
+	return d.Validate
+}
+
+// unquoteTagValue strips a single layer of surrounding double quotes,
+// e.g. `"A|B|C"` -> `A|B|C`, used by tag options whose value may itself
+// contain characters with special meaning in the tag grammar.
+func unquoteTagValue(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// resolveIntExpr interprets value either as a literal integer or as an
+// arithmetic expression (e.g. "FieldName*4+2") whose identifiers name a
+// field already decoded in structValue or, failing that, in the nearest
+// enclosing struct in parentStructValues (outermost last).
+func resolveIntExpr(structValue reflect.Value, parentStructValues []reflect.Value, value string) (int64, error) {
+	if n, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return n, nil
+	}
+
+	return evalExpr(value, func(name string) (int64, bool) {
+		if n, ok := intFieldByName(structValue, name); ok {
+			return n, true
+		}
+
+		for i := len(parentStructValues) - 1; i >= 0; i-- {
+			if n, ok := intFieldByName(parentStructValues[i], name); ok {
+				return n, true
+			}
+		}
+
+		return 0, false
+	})
+}
+
+// intFieldByName returns the integer value of the named field of sv, if
+// it exists and is an integer kind.
+func intFieldByName(sv reflect.Value, name string) (int64, bool) {
+	fv := sv.FieldByName(name)
+	if !fv.IsValid() {
+		return 0, false
+	}
+
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(fv.Uint()), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,24 @@
+package binstruct
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTagQuoteAware(t *testing.T) {
+	got, err := parseTag(`len:4,regex:"^[0-9]{2,4}$",align`)
+	if err != nil {
+		t.Fatalf("parseTag() error = %v", err)
+	}
+
+	want := []string{"len:4", `regex:"^[0-9]{2,4}$"`, "align"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseTag() = %v, want %v", got, want)
+	}
+}
+
+func TestParseTagUnbalancedQuotes(t *testing.T) {
+	if _, err := parseTag(`regex:"^[0-9]+$`); err == nil {
+		t.Fatal("parseTag() error = nil, want error for unbalanced quotes")
+	}
+}
@@ -1,6 +1,7 @@
 package binstruct
 
 import (
+	"io"
 	"reflect"
 	"strings"
 
@@ -9,6 +10,10 @@ import (
 
 type unmarshal struct {
 	r Reader
+
+	codecs   map[reflect.Type]Codec
+	variants map[variantKey]reflect.Type
+	bits     bitReader
 }
 
 // An InvalidUnmarshalError describes an invalid argument passed to Unmarshal.
@@ -41,6 +46,10 @@ func (u *unmarshal) unmarshal(v interface{}, parentStructValues []reflect.Value)
 	structValue := rv.Elem()
 	numField := structValue.NumField()
 
+	// bits:N fields only pack together within a single struct; entering
+	// a new one always starts on a byte boundary.
+	u.bits.align()
+
 	valueType := structValue.Type()
 	for i := 0; i < numField; i++ {
 		fieldType := valueType.Field(i)
@@ -49,7 +58,7 @@ func (u *unmarshal) unmarshal(v interface{}, parentStructValues []reflect.Value)
 			return errors.Wrapf(err, `failed parseTag for field "%s"`, fieldType.Name)
 		}
 
-		fieldData, err := parseReadDataFromTags(structValue, tags)
+		fieldData, err := parseReadDataFromTags(structValue, parentStructValues, tags)
 		if err != nil {
 			return errors.Wrapf(err, `failed parse ReadData from tags for field "%s"`, fieldType.Name)
 		}
@@ -61,6 +70,11 @@ func (u *unmarshal) unmarshal(v interface{}, parentStructValues []reflect.Value)
 		}
 	}
 
+	// Leaving the struct also lands on a byte boundary, so a nested
+	// struct's trailing partial bits never bleed into the parent's next
+	// bits:N field.
+	u.bits.align()
+
 	return nil
 }
 
@@ -73,11 +87,19 @@ func (u *unmarshal) setValueToField(structValue, fieldValue reflect.Value, field
 		return nil
 	}
 
+	if fieldData.Align {
+		u.bits.align()
+	}
+
 	err := setOffset(u.r, fieldData)
 	if err != nil {
 		return errors.Wrap(err, "set offset")
 	}
 
+	if fieldData.Bits != nil {
+		return u.decodeBits(fieldValue, fieldData)
+	}
+
 	if fieldData.FuncName != "" {
 		okCallFunc, err := callFunc(u.r, fieldData.FuncName, structValue, fieldValue)
 		if err != nil {
@@ -115,25 +137,33 @@ or
 		return nil
 	}
 
+	if codec := u.codecFor(fieldValue.Type()); codec != nil {
+		return callCodec(codec, u.r, fieldValue)
+	}
+
 	switch fieldValue.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		var value int64
 		var err error
 
+		// A len:N tag always wins over the field's own Kind: it tells us
+		// how many bytes are on the wire, which may differ from the
+		// field's width (e.g. len:8 widening into an int32), and the
+		// OverflowInt check below is what catches it not fitting back.
 		switch {
-		case fieldData.Length != nil && *fieldData.Length == 1 || fieldValue.Kind() == reflect.Int8:
+		case fieldData.Length != nil && *fieldData.Length == 1 || fieldData.Length == nil && fieldValue.Kind() == reflect.Int8:
 			v, e := u.r.ReadInt8()
 			value = int64(v)
 			err = e
-		case fieldData.Length != nil && *fieldData.Length == 2 || fieldValue.Kind() == reflect.Int16:
+		case fieldData.Length != nil && *fieldData.Length == 2 || fieldData.Length == nil && fieldValue.Kind() == reflect.Int16:
 			v, e := u.r.ReadInt16()
 			value = int64(v)
 			err = e
-		case fieldData.Length != nil && *fieldData.Length == 4 || fieldValue.Kind() == reflect.Int32:
+		case fieldData.Length != nil && *fieldData.Length == 4 || fieldData.Length == nil && fieldValue.Kind() == reflect.Int32:
 			v, e := u.r.ReadInt32()
 			value = int64(v)
 			err = e
-		case fieldData.Length != nil && *fieldData.Length == 8 || fieldValue.Kind() == reflect.Int64:
+		case fieldData.Length != nil && *fieldData.Length == 8 || fieldData.Length == nil && fieldValue.Kind() == reflect.Int64:
 			value, err = u.r.ReadInt64()
 		default: // reflect.Int:
 			err = errors.New("need set tag with len or use int8/int16/int32/int64")
@@ -142,27 +172,37 @@ or
 			return err
 		}
 
+		if fieldValue.OverflowInt(value) {
+			return errors.Errorf("value %d overflows field of type %s", value, fieldValue.Type())
+		}
+
 		if fieldValue.CanSet() {
 			fieldValue.SetInt(value)
 		}
+
+		if err := validateFieldValue(fieldData, fieldValue); err != nil {
+			return err
+		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		var value uint64
 		var err error
 
+		// See the analogous comment in the Int case above: len:N always
+		// wins over Kind.
 		switch {
-		case fieldData.Length != nil && *fieldData.Length == 1 || fieldValue.Kind() == reflect.Uint8:
+		case fieldData.Length != nil && *fieldData.Length == 1 || fieldData.Length == nil && fieldValue.Kind() == reflect.Uint8:
 			v, e := u.r.ReadUint8()
 			value = uint64(v)
 			err = e
-		case fieldData.Length != nil && *fieldData.Length == 2 || fieldValue.Kind() == reflect.Uint16:
+		case fieldData.Length != nil && *fieldData.Length == 2 || fieldData.Length == nil && fieldValue.Kind() == reflect.Uint16:
 			v, e := u.r.ReadUint16()
 			value = uint64(v)
 			err = e
-		case fieldData.Length != nil && *fieldData.Length == 4 || fieldValue.Kind() == reflect.Uint32:
+		case fieldData.Length != nil && *fieldData.Length == 4 || fieldData.Length == nil && fieldValue.Kind() == reflect.Uint32:
 			v, e := u.r.ReadUint32()
 			value = uint64(v)
 			err = e
-		case fieldData.Length != nil && *fieldData.Length == 8 || fieldValue.Kind() == reflect.Uint64:
+		case fieldData.Length != nil && *fieldData.Length == 8 || fieldData.Length == nil && fieldValue.Kind() == reflect.Uint64:
 			value, err = u.r.ReadUint64()
 		default: // reflect.Uint:
 			err = errors.New("need set tag with len or use uint8/uint16/uint32/uint64")
@@ -171,15 +211,27 @@ or
 			return err
 		}
 
+		if fieldValue.OverflowUint(value) {
+			return errors.Errorf("value %d overflows field of type %s", value, fieldValue.Type())
+		}
+
 		if fieldValue.CanSet() {
 			fieldValue.SetUint(value)
 		}
+
+		if err := validateFieldValue(fieldData, fieldValue); err != nil {
+			return err
+		}
 	case reflect.Float32:
 		f, err := u.r.ReadFloat32()
 		if err != nil {
 			return err
 		}
 
+		if fieldValue.OverflowFloat(float64(f)) {
+			return errors.Errorf("value %v overflows field of type %s", f, fieldValue.Type())
+		}
+
 		if fieldValue.CanSet() {
 			fieldValue.SetFloat(float64(f))
 		}
@@ -189,6 +241,10 @@ or
 			return err
 		}
 
+		if fieldValue.OverflowFloat(f) {
+			return errors.Errorf("value %v overflows field of type %s", f, fieldValue.Type())
+		}
+
 		if fieldValue.CanSet() {
 			fieldValue.SetFloat(f)
 		}
@@ -214,20 +270,77 @@ or
 		if fieldValue.CanSet() {
 			fieldValue.SetString(string(b))
 		}
-	case reflect.Slice:
-		if fieldData.Length == nil {
-			return errors.New("need set tag with len for slice")
+
+		if err := validateFieldValue(fieldData, fieldValue); err != nil {
+			return err
 		}
+	case reflect.Slice:
+		switch {
+		case fieldData.Length != nil:
+			for i := int64(0); i < *fieldData.Length; i++ {
+				tmpV := reflect.New(fieldValue.Type().Elem()).Elem()
+				err := u.setValueToField(structValue, tmpV, fieldData.ElemFieldData, parentStructValues)
+				if err != nil {
+					return err
+				}
+				if fieldValue.CanSet() {
+					fieldValue.Set(reflect.Append(fieldValue, tmpV))
+				}
+			}
 
-		for i := int64(0); i < *fieldData.Length; i++ {
-			tmpV := reflect.New(fieldValue.Type().Elem()).Elem()
-			err := u.setValueToField(structValue, tmpV, fieldData.ElemFieldData, parentStructValues)
-			if err != nil {
-				return err
+		case fieldData.LenEOF:
+			for {
+				tmpV := reflect.New(fieldValue.Type().Elem()).Elem()
+				err := u.setValueToField(structValue, tmpV, fieldData.ElemFieldData, parentStructValues)
+				if err != nil {
+					if errors.Cause(err) == io.EOF {
+						break
+					}
+					return err
+				}
+				if fieldValue.CanSet() {
+					fieldValue.Set(reflect.Append(fieldValue, tmpV))
+				}
 			}
-			if fieldValue.CanSet() {
-				fieldValue.Set(reflect.Append(fieldValue, tmpV))
+
+		case fieldData.Until != nil:
+			for {
+				peeked, err := u.r.Peek(1)
+				if err != nil {
+					if errors.Cause(err) == io.EOF {
+						return errors.New("until: sentinel not found before EOF")
+					}
+					return errors.Wrap(err, "peek until")
+				}
+
+				if int64(peeked[0]) == *fieldData.Until {
+					if fieldData.KeepTerminator {
+						tmpV := reflect.New(fieldValue.Type().Elem()).Elem()
+						err := u.setValueToField(structValue, tmpV, fieldData.ElemFieldData, parentStructValues)
+						if err != nil {
+							return err
+						}
+						if fieldValue.CanSet() {
+							fieldValue.Set(reflect.Append(fieldValue, tmpV))
+						}
+					} else if _, err := u.r.ReadUint8(); err != nil {
+						return err
+					}
+					break
+				}
+
+				tmpV := reflect.New(fieldValue.Type().Elem()).Elem()
+				err = u.setValueToField(structValue, tmpV, fieldData.ElemFieldData, parentStructValues)
+				if err != nil {
+					return err
+				}
+				if fieldValue.CanSet() {
+					fieldValue.Set(reflect.Append(fieldValue, tmpV))
+				}
 			}
+
+		default:
+			return errors.New("need set tag with len for slice")
 		}
 	case reflect.Array:
 		var arrLen int64
@@ -255,6 +368,15 @@ or
 		if err != nil {
 			return errors.Wrap(err, "unmarshal struct")
 		}
+	case reflect.Interface:
+		if fieldData.Switch == "" {
+			return errors.New("need set tag with switch for interface field")
+		}
+
+		err := u.decodeVariant(structValue, fieldValue, fieldData, parentStructValues)
+		if err != nil {
+			return errors.Wrap(err, "decode variant")
+		}
 	default:
 		return errors.New(`type "` + fieldValue.Kind().String() + `" not supported`)
 	}
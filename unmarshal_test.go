@@ -0,0 +1,32 @@
+package binstruct
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUnmarshalLenWidensPastFieldKind(t *testing.T) {
+	type widened struct {
+		A int32 `bin:"len:8"`
+	}
+
+	// Fits: the 8-byte value is small enough to fit back into an int32.
+	var w widened
+	data := []byte{0, 0, 0, 0, 0, 0, 0, 42}
+	if err := Unmarshal(bytes.NewReader(data), &w); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if w.A != 42 {
+		t.Fatalf("A = %d, want 42", w.A)
+	}
+
+	// Doesn't fit: len:8 must actually read 8 bytes (not fall back to the
+	// field's own int32 width), so a value outside int32's range is
+	// caught by the overflow check instead of being silently truncated.
+	var w2 widened
+	overflow := []byte{0, 0, 0, 1, 0, 0, 0, 0}
+	err := Unmarshal(bytes.NewReader(overflow), &w2)
+	if err == nil {
+		t.Fatal("Unmarshal() error = nil, want overflow error")
+	}
+}
@@ -0,0 +1,81 @@
+package binstruct
+
+import (
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// validateFieldValue checks fieldValue against the validation tags
+// (min, max, in, regex, magic) parsed into fieldData.Validate. It is
+// called immediately after a field is decoded.
+func validateFieldValue(fieldData *fieldReadData, fieldValue reflect.Value) error {
+	if fieldData == nil || fieldData.Validate == nil {
+		return nil
+	}
+
+	v := fieldData.Validate
+
+	if v.Min != nil || v.Max != nil || v.Magic != nil {
+		n, ok := asInt64(fieldValue)
+		if !ok {
+			return errors.Errorf(`min/max/magic not supported for type "%s"`, fieldValue.Type())
+		}
+
+		if v.Min != nil && n < *v.Min {
+			return errors.Errorf("value %d is less than min %d", n, *v.Min)
+		}
+		if v.Max != nil && n > *v.Max {
+			return errors.Errorf("value %d is greater than max %d", n, *v.Max)
+		}
+		if v.Magic != nil && uint64(n) != *v.Magic {
+			return errors.Errorf("value 0x%x does not match magic 0x%x", uint64(n), *v.Magic)
+		}
+	}
+
+	if v.In != nil {
+		s := asString(fieldValue)
+
+		var found bool
+		for _, allowed := range v.In {
+			if s == allowed {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf(`value "%s" is not one of %v`, s, v.In)
+		}
+	}
+
+	if v.Regex != nil {
+		s := asString(fieldValue)
+		if !v.Regex.MatchString(s) {
+			return errors.Errorf(`value "%s" does not match regex "%s"`, s, v.Regex.String())
+		}
+	}
+
+	return nil
+}
+
+func asInt64(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	default:
+		return 0, false
+	}
+}
+
+func asString(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	if n, ok := asInt64(v); ok {
+		return strconv.FormatInt(n, 10)
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package binstruct
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestValidateMinMax(t *testing.T) {
+	type rec struct {
+		A uint8 `bin:"min:1,max:10"`
+	}
+
+	var r rec
+	if err := Unmarshal(bytes.NewReader([]byte{5}), &r); err != nil {
+		t.Fatalf("Unmarshal() error = %v, want nil", err)
+	}
+
+	if err := Unmarshal(bytes.NewReader([]byte{0}), &r); err == nil {
+		t.Fatal("Unmarshal() error = nil, want error for value below min")
+	}
+
+	if err := Unmarshal(bytes.NewReader([]byte{11}), &r); err == nil {
+		t.Fatal("Unmarshal() error = nil, want error for value above max")
+	}
+}
+
+func TestValidateIn(t *testing.T) {
+	type rec struct {
+		Code string `bin:"len:2,in:\"OK|NO\""`
+	}
+
+	var r rec
+	if err := Unmarshal(bytes.NewReader([]byte("OK")), &r); err != nil {
+		t.Fatalf("Unmarshal() error = %v, want nil", err)
+	}
+
+	if err := Unmarshal(bytes.NewReader([]byte("XX")), &r); err == nil {
+		t.Fatal("Unmarshal() error = nil, want error for value not in set")
+	}
+}
+
+func TestValidateRegex(t *testing.T) {
+	type rec struct {
+		Code string `bin:"len:3,regex:\"^[0-9]+$\""`
+	}
+
+	var r rec
+	if err := Unmarshal(bytes.NewReader([]byte("123")), &r); err != nil {
+		t.Fatalf("Unmarshal() error = %v, want nil", err)
+	}
+
+	if err := Unmarshal(bytes.NewReader([]byte("abc")), &r); err == nil {
+		t.Fatal("Unmarshal() error = nil, want error for value not matching regex")
+	}
+}
+
+func TestValidateRegexBoundedQuantifier(t *testing.T) {
+	type rec struct {
+		Code string `bin:"len:4,regex:\"^[0-9]{2,4}$\""`
+	}
+
+	var r rec
+	if err := Unmarshal(bytes.NewReader([]byte("1234")), &r); err != nil {
+		t.Fatalf("Unmarshal() error = %v, want nil", err)
+	}
+
+	if err := Unmarshal(bytes.NewReader([]byte("1abc")), &r); err == nil {
+		t.Fatal("Unmarshal() error = nil, want error for value not matching regex")
+	}
+}
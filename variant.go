@@ -0,0 +1,89 @@
+package binstruct
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// variantKey identifies one entry of the RegisterVariant registry: the
+// interface type being decoded, and the discriminator value that
+// selects a concrete type for it.
+type variantKey struct {
+	iface reflect.Type
+	tag   interface{}
+}
+
+// RegisterVariant registers concrete as the type to decode into an
+// interface field typed like iface when its `switch:"..."` discriminator
+// field equals tagValue. iface and concrete are only used for their
+// type, e.g.:
+//
+//	u.RegisterVariant((*Message)(nil), uint8(1), PingMessage{})
+func (u *unmarshal) RegisterVariant(iface interface{}, tagValue interface{}, concrete interface{}) {
+	if u.variants == nil {
+		u.variants = make(map[variantKey]reflect.Type)
+	}
+
+	ifaceType := reflect.TypeOf(iface)
+	if ifaceType.Kind() == reflect.Ptr {
+		ifaceType = ifaceType.Elem()
+	}
+
+	u.variants[variantKey{iface: ifaceType, tag: tagValue}] = reflect.TypeOf(concrete)
+}
+
+func (u *unmarshal) variantFor(ifaceType reflect.Type, tagValue interface{}) (reflect.Type, bool) {
+	if u.variants == nil {
+		return nil, false
+	}
+
+	t, ok := u.variants[variantKey{iface: ifaceType, tag: tagValue}]
+	return t, ok
+}
+
+// decodeVariant resolves fieldData.Switch to a discriminator value on
+// structValue, looks up the concrete type registered for it, and
+// unmarshals a new instance of that type into fieldValue.
+func (u *unmarshal) decodeVariant(structValue, fieldValue reflect.Value, fieldData *fieldReadData, parentStructValues []reflect.Value) error {
+	discriminator := structValue.FieldByName(fieldData.Switch)
+	if !discriminator.IsValid() {
+		return errors.Errorf(`switch field "%s" not found`, fieldData.Switch)
+	}
+
+	concreteType, ok := u.variantFor(fieldValue.Type(), discriminator.Interface())
+	if !ok {
+		return errors.Errorf(`no variant registered for %s = %v`, fieldData.Switch, discriminator.Interface())
+	}
+
+	isPtr := concreteType.Kind() == reflect.Ptr
+	if isPtr {
+		concreteType = concreteType.Elem()
+	}
+
+	concreteValue := reflect.New(concreteType)
+	err := u.unmarshal(concreteValue.Interface(), append(parentStructValues, structValue))
+	if err != nil {
+		return errors.Wrap(err, "unmarshal variant")
+	}
+
+	if !fieldValue.CanSet() {
+		return nil
+	}
+
+	result := concreteValue
+	if !isPtr {
+		result = concreteValue.Elem()
+	}
+
+	if !result.Type().AssignableTo(fieldValue.Type()) {
+		return errors.Errorf(
+			`variant %s registered for %s = %v is not assignable to field of type %s`,
+			result.Type(), fieldData.Switch, discriminator.Interface(), fieldValue.Type(),
+		)
+	}
+
+	fieldValue.Set(result)
+
+	return nil
+}
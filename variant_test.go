@@ -0,0 +1,58 @@
+package binstruct
+
+import (
+	"bytes"
+	"testing"
+)
+
+type message interface {
+	isMessage()
+}
+
+type pingMsg struct {
+	Payload uint8
+}
+
+func (pingMsg) isMessage() {}
+
+// notAMessage is intentionally never assignable to the message interface,
+// to exercise a misregistered variant.
+type notAMessage struct {
+	Payload uint8
+}
+
+func TestDecodeVariant(t *testing.T) {
+	type packet struct {
+		Kind uint8
+		Body message `bin:"switch:Kind"`
+	}
+
+	dec := NewDecoder(bytes.NewReader([]byte{1, 0x42}))
+	dec.RegisterVariant((*message)(nil), uint8(1), pingMsg{})
+
+	var p packet
+	if err := dec.Unmarshal(&p); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	body, ok := p.Body.(pingMsg)
+	if !ok || body.Payload != 0x42 {
+		t.Fatalf("Body = %#v, want pingMsg{Payload: 0x42}", p.Body)
+	}
+}
+
+func TestDecodeVariantNotAssignable(t *testing.T) {
+	type packet struct {
+		Kind uint8
+		Body message `bin:"switch:Kind"`
+	}
+
+	dec := NewDecoder(bytes.NewReader([]byte{1, 0x42}))
+	dec.RegisterVariant((*message)(nil), uint8(1), notAMessage{})
+
+	var p packet
+	err := dec.Unmarshal(&p)
+	if err == nil {
+		t.Fatal("Unmarshal() error = nil, want error for misregistered variant")
+	}
+}
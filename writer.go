@@ -0,0 +1,120 @@
+package binstruct
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Writer is the interface used by the marshaler to push typed values
+// into the underlying stream. It wraps io.Writer/io.Seeker with the
+// helpers needed to encode the `bin` tag grammar.
+type Writer interface {
+	io.Writer
+	io.Seeker
+
+	WriteBytes(b []byte) (int64, error)
+
+	WriteBool(v bool) error
+
+	WriteInt8(v int8) error
+	WriteInt16(v int16) error
+	WriteInt32(v int32) error
+	WriteInt64(v int64) error
+
+	WriteUint8(v uint8) error
+	WriteUint16(v uint16) error
+	WriteUint32(v uint32) error
+	WriteUint64(v uint64) error
+
+	WriteFloat32(v float32) error
+	WriteFloat64(v float64) error
+}
+
+type writer struct {
+	w         io.Writer
+	byteOrder binary.ByteOrder
+}
+
+// NewWriter returns a Writer that encodes big-endian values to w. Seek
+// is only supported when w also implements io.Seeker, which is required
+// to honor `offset` tags.
+func NewWriter(w io.Writer) Writer {
+	return &writer{w: w, byteOrder: binary.BigEndian}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+func (w *writer) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := w.w.(io.Seeker)
+	if !ok {
+		return 0, errors.New("binstruct: underlying writer does not support Seek, required for offset tags")
+	}
+	return seeker.Seek(offset, whence)
+}
+
+func (w *writer) WriteBytes(b []byte) (int64, error) {
+	n, err := w.w.Write(b)
+	return int64(n), err
+}
+
+func (w *writer) WriteBool(v bool) error {
+	if v {
+		return w.WriteUint8(1)
+	}
+	return w.WriteUint8(0)
+}
+
+func (w *writer) WriteInt8(v int8) error {
+	return w.WriteUint8(uint8(v))
+}
+
+func (w *writer) WriteInt16(v int16) error {
+	return w.WriteUint16(uint16(v))
+}
+
+func (w *writer) WriteInt32(v int32) error {
+	return w.WriteUint32(uint32(v))
+}
+
+func (w *writer) WriteInt64(v int64) error {
+	return w.WriteUint64(uint64(v))
+}
+
+func (w *writer) WriteUint8(v uint8) error {
+	_, err := w.WriteBytes([]byte{v})
+	return err
+}
+
+func (w *writer) WriteUint16(v uint16) error {
+	b := make([]byte, 2)
+	w.byteOrder.PutUint16(b, v)
+	_, err := w.WriteBytes(b)
+	return err
+}
+
+func (w *writer) WriteUint32(v uint32) error {
+	b := make([]byte, 4)
+	w.byteOrder.PutUint32(b, v)
+	_, err := w.WriteBytes(b)
+	return err
+}
+
+func (w *writer) WriteUint64(v uint64) error {
+	b := make([]byte, 8)
+	w.byteOrder.PutUint64(b, v)
+	_, err := w.WriteBytes(b)
+	return err
+}
+
+func (w *writer) WriteFloat32(v float32) error {
+	return w.WriteUint32(math.Float32bits(v))
+}
+
+func (w *writer) WriteFloat64(v float64) error {
+	return w.WriteUint64(math.Float64bits(v))
+}